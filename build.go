@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// buildIssueRe matches xcodebuild's standard "file:line:col: error|warning: message" format.
+var buildIssueRe = regexp.MustCompile(`^(.+):(\d+):(\d+): (error|warning): (.*)$`)
+
+// noSchemeRe recognizes xcodebuild's "does not contain a scheme named"
+// error (capturing the missing scheme's name) so we can tell the user which
+// schemes actually exist.
+var noSchemeRe = regexp.MustCompile(`does not contain a scheme named "([^"]+)"`)
+
+// BuildSummary is the machine-readable result of a xcodebuild invocation,
+// emitted as JSON when -json is passed.
+type BuildSummary struct {
+	Errors   []string `json:"errors"`
+	Warnings []string `json:"warnings"`
+	Duration float64  `json:"duration"`
+}
+
+// RunBuild executes `xcodebuild <args...> build`, pretty-printing through
+// xcbeautify or xcpretty when either is on PATH (falling back to raw
+// output otherwise), always mirroring the full raw log to
+// ./DerivedData/xcode-runner-<timestamp>.log, and returning a BuildSummary
+// of every error/warning line it saw.
+func RunBuild(args []string) (*BuildSummary, error) {
+	if err := os.MkdirAll("DerivedData", 0755); err != nil {
+		return nil, fmt.Errorf("error creating DerivedData directory: %s", err)
+	}
+	logPath := filepath.Join("DerivedData", fmt.Sprintf("xcode-runner-%d.log", time.Now().Unix()))
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		return nil, fmt.Errorf("error creating build log %s: %s", logPath, err)
+	}
+	defer logFile.Close()
+
+	dest, closePretty := startPrettyPrinter()
+	defer closePretty()
+
+	summary := &BuildSummary{}
+	tee := &buildTee{logFile: logFile, dest: dest, summary: summary}
+
+	start := time.Now()
+	cmd := exec.Command("xcodebuild", append(args, "build")...)
+	cmd.Stdout = tee
+	cmd.Stderr = tee
+	runErr := cmd.Run()
+	summary.Duration = time.Since(start).Seconds()
+
+	fmt.Println("📄 Full build log:", logPath)
+
+	return summary, runErr
+}
+
+// startPrettyPrinter returns a writer that pipes through xcbeautify or
+// xcpretty when one is available on PATH, and a cleanup func that must be
+// called once the build is done writing to it. Falls back to os.Stdout
+// when neither tool is installed.
+func startPrettyPrinter() (io.Writer, func()) {
+	var prettyCmd *exec.Cmd
+	for _, name := range []string{"xcbeautify", "xcpretty"} {
+		if path, err := exec.LookPath(name); err == nil {
+			prettyCmd = exec.Command(path)
+			break
+		}
+	}
+	if prettyCmd == nil {
+		return os.Stdout, func() {}
+	}
+
+	prettyCmd.Stdout = os.Stdout
+	prettyCmd.Stderr = os.Stderr
+	stdin, err := prettyCmd.StdinPipe()
+	if err != nil || prettyCmd.Start() != nil {
+		return os.Stdout, func() {}
+	}
+
+	return stdin, func() {
+		stdin.Close()
+		prettyCmd.Wait()
+	}
+}
+
+// buildTee fans every line of xcodebuild's combined output out to the raw
+// log file, the pretty-printer (or stdout), and the structured summary.
+type buildTee struct {
+	mu      sync.Mutex
+	logFile *os.File
+	dest    io.Writer
+	summary *BuildSummary
+	buf     []byte
+}
+
+func (t *buildTee) Write(data []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.buf = append(t.buf, data...)
+	for {
+		i := bytes.IndexByte(t.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := t.buf[:i]
+		t.buf = t.buf[i+1:]
+
+		t.logFile.Write(line)
+		t.logFile.Write([]byte("\n"))
+
+		if match := buildIssueRe.FindSubmatch(line); match != nil {
+			message := fmt.Sprintf("%s:%s:%s: %s", match[1], match[2], match[3], match[5])
+			if string(match[4]) == "error" {
+				t.summary.Errors = append(t.summary.Errors, message)
+			} else {
+				t.summary.Warnings = append(t.summary.Warnings, message)
+			}
+		}
+
+		t.dest.Write(line)
+		t.dest.Write([]byte("\n"))
+	}
+	return len(data), nil
+}
+
+// BuildSettingsErrorHint inspects a GetBuildSettings error for xcodebuild's
+// "does not contain a scheme named" message and, if found, returns a
+// helpful hint listing the schemes that do exist. Returns "" otherwise.
+func BuildSettingsErrorHint(err error, schemes []string) string {
+	if err == nil {
+		return ""
+	}
+	if !noSchemeRe.MatchString(err.Error()) {
+		return ""
+	}
+	return fmt.Sprintf("💡 Available schemes: %s", strings.Join(schemes, ", "))
+}
+
+// AcquireBuildLock takes an exclusive flock on ~/.xcode-runner.lock so two
+// concurrent xcode-runner invocations (e.g. from editor integrations)
+// can't clobber the same DerivedData directory. It blocks until the lock
+// is free and returns a function that releases it.
+func AcquireBuildLock() (func(), error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("error resolving home directory: %s", err)
+	}
+
+	lockPath := filepath.Join(home, ".xcode-runner.lock")
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening lock file %s: %s", lockPath, err)
+	}
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("error acquiring build lock: %s", err)
+	}
+
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// configFileName is the workspace-local cache of the last scheme/device/
+// configuration picked, plus any hand-written per-scheme overrides.
+const configFileName = ".xcode-runner.json"
+
+// SchemeOverride lets a workspace hand-tune how a specific scheme is built
+// and launched without touching the Xcode project.
+type SchemeOverride struct {
+	XCConfigPath string            `json:"xcconfigPath,omitempty"`
+	ExtraArgs    []string          `json:"extraArgs,omitempty"`
+	Env          map[string]string `json:"env,omitempty"`
+}
+
+// WorkspaceConfig is the on-disk shape of configFileName.
+type WorkspaceConfig struct {
+	Scheme          string                    `json:"scheme,omitempty"`
+	DeviceUDID      string                    `json:"deviceUDID,omitempty"`
+	Configuration   string                    `json:"configuration,omitempty"`
+	Destination     string                    `json:"destination,omitempty"`
+	SchemeOverrides map[string]SchemeOverride `json:"schemeOverrides,omitempty"`
+}
+
+// LoadWorkspaceConfig reads configFileName from the current directory. A
+// missing file is not an error; it just yields a zero-value config.
+func LoadWorkspaceConfig() (*WorkspaceConfig, error) {
+	data, err := os.ReadFile(configFileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &WorkspaceConfig{}, nil
+		}
+		return nil, fmt.Errorf("error reading %s: %s", configFileName, err)
+	}
+
+	var cfg WorkspaceConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %s", configFileName, err)
+	}
+	return &cfg, nil
+}
+
+// SaveWorkspaceConfig writes cfg to configFileName, replacing it atomically
+// via a temp file + rename so a crash mid-write can't corrupt it.
+func SaveWorkspaceConfig(cfg *WorkspaceConfig) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding %s: %s", configFileName, err)
+	}
+
+	tmp, err := os.CreateTemp(".", ".xcode-runner.*.tmp")
+	if err != nil {
+		return fmt.Errorf("error creating temp config file: %s", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing temp config file: %s", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error closing temp config file: %s", err)
+	}
+	if err := os.Rename(tmp.Name(), configFileName); err != nil {
+		return fmt.Errorf("error saving %s: %s", configFileName, err)
+	}
+	return nil
+}
+
+// envArgs renders a scheme override's Env map as sorted "KEY=VALUE" pairs,
+// suitable for appending to a `simctl launch` invocation.
+func (o SchemeOverride) envArgs() []string {
+	if len(o.Env) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(o.Env))
+	for k := range o.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	args := make([]string, 0, len(keys))
+	for _, k := range keys {
+		args = append(args, fmt.Sprintf("%s=%s", k, o.Env[k]))
+	}
+	return args
+}
+
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// maxConcurrentDeploys bounds how many devices DeployAll installs to at
+// once, so we don't overwhelm simctl/devicectl or interleave too many logs.
+const maxConcurrentDeploys = 4
+
+// ResolveDeployTargets turns a -devices flag value into a list of UDIDs.
+// value is a comma-separated list of device names or UDIDs, or one of the
+// special keywords "all-sims" / "all-devices".
+func ResolveDeployTargets(value string, devices map[string]string, isSimulator map[string]bool) ([]string, error) {
+	switch value {
+	case "all-sims":
+		return udidsWhere(devices, isSimulator, true), nil
+	case "all-devices":
+		return udidsWhere(devices, isSimulator, false), nil
+	}
+
+	udidSet := make(map[string]bool, len(devices))
+	for _, udid := range devices {
+		udidSet[udid] = true
+	}
+
+	var udids []string
+	for _, token := range strings.Split(value, ",") {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+		if udid, ok := devices[token]; ok {
+			udids = append(udids, udid)
+		} else if udidSet[token] {
+			udids = append(udids, token)
+		} else {
+			return nil, fmt.Errorf("unknown device %q", token)
+		}
+	}
+
+	if len(udids) == 0 {
+		return nil, fmt.Errorf("no devices matched -devices %q", value)
+	}
+	if err := requireHomogeneous(udids, isSimulator); err != nil {
+		return nil, err
+	}
+	return udids, nil
+}
+
+// requireHomogeneous rejects a -devices list that mixes simulators and
+// physical devices: DeployAll builds and deploys one appPath with one tool
+// for the whole list, so a mixed set would install the wrong artifact via
+// the wrong tool to half its targets.
+func requireHomogeneous(udids []string, isSimulator map[string]bool) error {
+	var sawSim, sawDevice bool
+	for _, udid := range udids {
+		if isSimulator[udid] {
+			sawSim = true
+		} else {
+			sawDevice = true
+		}
+	}
+	if sawSim && sawDevice {
+		return fmt.Errorf("-devices cannot mix simulators and physical devices in one invocation")
+	}
+	return nil
+}
+
+func udidsWhere(devices map[string]string, isSimulator map[string]bool, wantSimulator bool) []string {
+	var udids []string
+	for _, udid := range devices {
+		if isSimulator[udid] == wantSimulator {
+			udids = append(udids, udid)
+		}
+	}
+	return udids
+}
+
+// DeployTarget installs appPath and launches bundleID on a single device,
+// prefixing every line of its output with the device's name. env holds
+// "KEY=VALUE" pairs to inject into the launched process; it's only honored
+// on simulators, via `simctl launch --terminate-running-process`.
+func DeployTarget(name, udid, appPath, bundleID string, isSim bool, env []string) error {
+	out := newPrefixWriter(os.Stdout, name)
+
+	if isSim {
+		runPrefixed(out, "xcrun", "simctl", "bootstatus", udid, "-b")
+		if err := runPrefixed(out, "xcrun", "simctl", "install", udid, appPath); err != nil {
+			return fmt.Errorf("%s: install failed: %s", name, err)
+		}
+		launchArgs := []string{"simctl", "launch"}
+		if len(env) > 0 {
+			launchArgs = append(launchArgs, "--terminate-running-process")
+		}
+		launchArgs = append(launchArgs, udid, bundleID)
+		launchArgs = append(launchArgs, env...)
+		if err := runPrefixed(out, "xcrun", launchArgs...); err != nil {
+			return fmt.Errorf("%s: launch failed: %s", name, err)
+		}
+		return nil
+	}
+
+	if err := runPrefixed(out, "xcrun", "devicectl", "device", "install", "app", "--device", udid, "--bundle", appPath); err != nil {
+		return fmt.Errorf("%s: install failed: %s", name, err)
+	}
+	if err := runPrefixed(out, "xcrun", "devicectl", "device", "process", "launch", "--device", udid, "--start-stopped", bundleID); err != nil {
+		return fmt.Errorf("%s: launch failed: %s", name, err)
+	}
+	return nil
+}
+
+// DeployAll runs DeployTarget for every UDID concurrently, bounded by
+// maxConcurrentDeploys, and joins any per-device failures into one error.
+func DeployAll(udids []string, names map[string]string, isSim bool, appPath, bundleID string, env []string) error {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentDeploys)
+	errCh := make(chan error, len(udids))
+
+	for _, udid := range udids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(udid string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			name := names[udid]
+			if name == "" {
+				name = udid
+			}
+			if err := DeployTarget(name, udid, appPath, bundleID, isSim, env); err != nil {
+				errCh <- err
+			}
+		}(udid)
+	}
+
+	wg.Wait()
+	close(errCh)
+
+	var errs []error
+	for err := range errCh {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func runPrefixed(w io.Writer, command string, args ...string) error {
+	cmd := exec.Command(command, args...)
+	cmd.Stdout = w
+	cmd.Stderr = w
+	return cmd.Run()
+}
+
+// prefixWriter line-buffers writes and prefixes each complete line with
+// "[name] " before forwarding it to the underlying writer, so interleaved
+// output from concurrent deploys stays readable.
+type prefixWriter struct {
+	mu     sync.Mutex
+	dest   io.Writer
+	prefix string
+	buf    []byte
+}
+
+func newPrefixWriter(dest io.Writer, name string) *prefixWriter {
+	return &prefixWriter{dest: dest, prefix: fmt.Sprintf("[%s] ", name)}
+}
+
+func (p *prefixWriter) Write(data []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.buf = append(p.buf, data...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			break
+		}
+		fmt.Fprintf(p.dest, "%s%s\n", p.prefix, p.buf[:i])
+		p.buf = p.buf[i+1:]
+	}
+	return len(data), nil
+}
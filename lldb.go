@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// devicectlLaunchResult is the subset of `devicectl device process launch
+// --json-output -` we care about: the PID of the process it started
+// suspended.
+type devicectlLaunchResult struct {
+	Result struct {
+		Process struct {
+			ProcessIdentifier int `json:"processIdentifier"`
+		} `json:"process"`
+	} `json:"result"`
+}
+
+// DebugSimulator launches bundleID suspended on the simulator udid and
+// attaches an interactive LLDB session to it, handing stdin/stdout/stderr to
+// the user.
+func DebugSimulator(udid, bundleID string) error {
+	fmt.Println("\n🐛 Launching suspended & attaching LLDB...")
+
+	output, err := RunShellCommand("xcrun", "simctl", "launch", "--wait-for-debugger", udid, bundleID)
+	if err != nil {
+		return fmt.Errorf("error launching app suspended: %s", err)
+	}
+
+	pid, err := parseSimctlLaunchPID(output)
+	if err != nil {
+		return err
+	}
+
+	return attachLLDBSimulator(pid)
+}
+
+// DebugDevice launches bundleID suspended on the physical device udid and
+// attaches an interactive LLDB session to it.
+func DebugDevice(udid, bundleID string) error {
+	fmt.Println("\n🐛 Launching suspended & attaching LLDB...")
+
+	cmd := exec.Command("xcrun", "devicectl", "device", "process", "launch",
+		"--device", udid, "--start-stopped", "--json-output", "-", bundleID)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error launching app suspended: %s", err)
+	}
+
+	pid, err := parseDevicectlLaunchPID(out.Bytes())
+	if err != nil {
+		return err
+	}
+
+	return attachLLDBDevice(udid, pid)
+}
+
+// parseSimctlLaunchPID extracts the PID from `simctl launch` output, which
+// looks like "<bundleID>: <pid>".
+func parseSimctlLaunchPID(output string) (int, error) {
+	_, pidStr, found := strings.Cut(strings.TrimSpace(output), ": ")
+	if !found {
+		return 0, fmt.Errorf("unexpected simctl launch output: %q", output)
+	}
+	pid, err := strconv.Atoi(pidStr)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing pid from simctl launch output: %s", err)
+	}
+	return pid, nil
+}
+
+// parseDevicectlLaunchPID extracts the process identifier from the JSON
+// emitted by `devicectl device process launch --json-output -`.
+func parseDevicectlLaunchPID(jsonOutput []byte) (int, error) {
+	var result devicectlLaunchResult
+	if err := json.Unmarshal(jsonOutput, &result); err != nil {
+		return 0, fmt.Errorf("error parsing devicectl launch output: %s", err)
+	}
+	if result.Result.Process.ProcessIdentifier == 0 {
+		return 0, fmt.Errorf("devicectl launch output did not contain a process identifier")
+	}
+	return result.Result.Process.ProcessIdentifier, nil
+}
+
+// attachLLDBSimulator generates a one-shot LLDB command file that attaches
+// to pid on the host Mac (the simulator's app process runs there), sets a
+// breakpoint on main and resumes it, then hands off to runLLDB.
+func attachLLDBSimulator(pid int) error {
+	commands := fmt.Sprintf("attach --pid %d\nbreakpoint set --name main\ncontinue\n", pid)
+	return runLLDB(commands, pid)
+}
+
+// attachLLDBDevice generates a one-shot LLDB command file that selects the
+// remote-ios platform and connects it to the physical device udid before
+// attaching, since pid is a device-side process identifier: a bare `attach
+// --pid` resolves against the host Mac's process list and would never find
+// it (or would attach to an unrelated host process sharing the same PID).
+func attachLLDBDevice(udid string, pid int) error {
+	commands := fmt.Sprintf(
+		"platform select remote-ios\nplatform connect connect://%s\nattach --pid %d\nbreakpoint set --name main\ncontinue\n",
+		udid, pid)
+	return runLLDB(commands, pid)
+}
+
+// runLLDB writes commands to a one-shot LLDB command file and execs lldb
+// against it with stdin, stdout and stderr wired to the user's terminal.
+// SIGINT is forwarded to lldb so Ctrl-C interrupts the debugger instead of
+// killing xcode-runner.
+func runLLDB(commands string, pid int) error {
+	cmdFile, err := os.CreateTemp("", "xcode-runner-lldb-*.txt")
+	if err != nil {
+		return fmt.Errorf("error creating lldb command file: %s", err)
+	}
+	defer os.Remove(cmdFile.Name())
+
+	if _, err := cmdFile.WriteString(commands); err != nil {
+		cmdFile.Close()
+		return fmt.Errorf("error writing lldb command file: %s", err)
+	}
+	cmdFile.Close()
+
+	lldbCmd := exec.Command("lldb", "-s", cmdFile.Name())
+	lldbCmd.Stdin = os.Stdin
+	lldbCmd.Stdout = os.Stdout
+	lldbCmd.Stderr = os.Stderr
+
+	if err := lldbCmd.Start(); err != nil {
+		return fmt.Errorf("error starting lldb: %s", err)
+	}
+	defer lldbCmd.Process.Kill()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT)
+	defer signal.Stop(sigCh)
+	go func() {
+		for range sigCh {
+			lldbCmd.Process.Signal(syscall.SIGINT)
+		}
+	}()
+
+	fmt.Println("🐛 LLDB attached to pid", pid)
+	return lldbCmd.Wait()
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// logLevelColors maps the level word `log stream --style=compact` prints at
+// the start of a line to the ANSI color it should be rendered in, in
+// priority order so a line naming more than one level colors consistently.
+var logLevelColors = []struct {
+	re    *regexp.Regexp
+	color string
+}{
+	{regexp.MustCompile(`\bFault\b`), "\x1b[1;31m"},
+	{regexp.MustCompile(`\bError\b`), "\x1b[31m"},
+	{regexp.MustCompile(`\bInfo\b`), "\x1b[36m"},
+	{regexp.MustCompile(`\bDebug\b`), "\x1b[90m"},
+}
+
+const ansiReset = "\x1b[0m"
+
+// LogStreamer tails device/simulator logs, colorizing known levels and
+// dropping lines that match an optional filter regex.
+type LogStreamer struct {
+	filter *regexp.Regexp
+}
+
+// NewLogStreamer compiles filterPattern (ignored if empty) and returns a
+// LogStreamer ready to attach to a log source.
+func NewLogStreamer(filterPattern string) (*LogStreamer, error) {
+	var filter *regexp.Regexp
+	if filterPattern != "" {
+		compiled, err := regexp.Compile(filterPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -log-filter: %s", err)
+		}
+		filter = compiled
+	}
+	return &LogStreamer{filter: filter}, nil
+}
+
+// StreamSimulatorLogs streams the simulator's unified log, filtered to
+// bundleID's subsystem, until ctx is cancelled.
+func (ls *LogStreamer) StreamSimulatorLogs(ctx context.Context, udid, bundleID string) error {
+	cmd := exec.CommandContext(ctx, "xcrun", "simctl", "spawn", udid, "log", "stream",
+		"--level=debug", "--style=compact", "--predicate", fmt.Sprintf(`subsystem == "%s"`, bundleID))
+	return ls.run(cmd, "")
+}
+
+// StreamDeviceLogs streams logs for pid on the physical device udid, until
+// ctx is cancelled. devicectl (via xcrun) is the primary mechanism;
+// idevicesyslog is only used as a fallback when xcrun isn't on PATH, and in that case
+// the stream is filtered down to lines naming pid since idevicesyslog has
+// no concept of "just this process".
+func (ls *LogStreamer) StreamDeviceLogs(ctx context.Context, udid string, pid int) error {
+	pidMarker := ""
+	var cmd *exec.Cmd
+	if path, err := exec.LookPath("xcrun"); err == nil {
+		cmd = exec.CommandContext(ctx, path, "devicectl", "device", "process", "view",
+			"--device", udid, "--pid", strconv.Itoa(pid))
+	} else if path, err := exec.LookPath("idevicesyslog"); err == nil {
+		cmd = exec.CommandContext(ctx, path, "-u", udid)
+		pidMarker = fmt.Sprintf("[%d]", pid)
+	} else {
+		return fmt.Errorf("neither xcrun nor idevicesyslog found on PATH")
+	}
+	return ls.run(cmd, pidMarker)
+}
+
+func (ls *LogStreamer) run(cmd *exec.Cmd, requireSubstring string) error {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("error attaching to log output: %s", err)
+	}
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("error starting log stream: %s", err)
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if requireSubstring != "" && !strings.Contains(line, requireSubstring) {
+			continue
+		}
+		if ls.filter != nil && ls.filter.MatchString(line) {
+			continue
+		}
+		fmt.Println(colorizeLogLine(line))
+	}
+
+	return cmd.Wait()
+}
+
+// colorizeLogLine wraps line in the ANSI color for the highest-priority log
+// level it recognizes, or returns it unchanged.
+func colorizeLogLine(line string) string {
+	for _, level := range logLevelColors {
+		if level.re.MatchString(line) {
+			return level.color + line + ansiReset
+		}
+	}
+	return line
+}
+
+// launchDeviceCapturingPID launches bundleID on the physical device udid and
+// returns its PID, parsed from devicectl's JSON output.
+func launchDeviceCapturingPID(udid, bundleID string) (int, error) {
+	cmd := exec.Command("xcrun", "devicectl", "device", "process", "launch",
+		"--device", udid, "--json-output", "-", bundleID)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return 0, fmt.Errorf("error launching app: %s", err)
+	}
+	return parseDevicectlLaunchPID(out.Bytes())
+}
+
+// StreamLogs attaches a LogStreamer to the already-launched app on udid
+// until Ctrl-C, then terminates the remote process (the caller is
+// responsible for not calling this when -lldb already owns the process).
+func StreamLogs(filterPattern string, isSim bool, udid, bundleID string, pid int) error {
+	streamer, err := NewLogStreamer(filterPattern)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT)
+	defer stop()
+
+	fmt.Printf("\n📜 Streaming logs for %s (Ctrl-C to stop)...\n", bundleID)
+
+	if isSim {
+		err = streamer.StreamSimulatorLogs(ctx, udid, bundleID)
+	} else {
+		err = streamer.StreamDeviceLogs(ctx, udid, pid)
+	}
+	if err != nil && ctx.Err() == nil {
+		return err
+	}
+
+	if isSim {
+		exec.Command("xcrun", "simctl", "terminate", udid, bundleID).Run()
+	} else {
+		exec.Command("xcrun", "devicectl", "device", "process", "signal",
+			"--device", udid, "--pid", strconv.Itoa(pid), "--signal", "SIGTERM").Run()
+	}
+	return nil
+}
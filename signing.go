@@ -0,0 +1,237 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+)
+
+// SigningConfig holds everything xcodebuild needs to code-sign a
+// physical-device build without relying on Xcode-managed signing.
+type SigningConfig struct {
+	DevID       string // CODE_SIGN_IDENTITY, e.g. "Apple Development: Jane Doe (ABCDE12345)"
+	TeamID      string // DEVELOPMENT_TEAM
+	AppIDPrefix string // application-identifier prefix, e.g. "ABCDE12345"
+	ProfileUUID string // PROVISIONING_PROFILE_SPECIFIER
+}
+
+type signingIdentity struct {
+	SHA1       string
+	CommonName string
+}
+
+type provisioningProfile struct {
+	UUID        string
+	TeamID      string
+	AppIDPrefix string
+	ExpiresAt   string
+	CommonName  string
+	CertSHA1s   []string
+}
+
+var identityRe = regexp.MustCompile(`(?m)^\s*\d+\)\s+([0-9A-F]{40})\s+"((?:Apple Development|iPhone Developer)[^"]*)"`)
+
+// DetectSigning enumerates the valid code-signing identities and
+// provisioning profiles installed on this machine and, when there's more
+// than one candidate, prompts the user to pick. GOIOS_DEV_ID, GOIOS_TEAM_ID
+// and GOIOS_APP_ID, when set, override the corresponding selected value;
+// they are applied after detection and do not skip any prompt.
+func DetectSigning() (*SigningConfig, error) {
+	identities, err := findSigningIdentities()
+	if err != nil {
+		return nil, err
+	}
+
+	profiles, err := findProvisioningProfiles()
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := selectIdentity(identities)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := selectProfile(profiles, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &SigningConfig{
+		DevID:       identity.CommonName,
+		TeamID:      profile.TeamID,
+		AppIDPrefix: profile.AppIDPrefix,
+		ProfileUUID: profile.UUID,
+	}
+
+	if v := os.Getenv("GOIOS_DEV_ID"); v != "" {
+		config.DevID = v
+	}
+	if v := os.Getenv("GOIOS_TEAM_ID"); v != "" {
+		config.TeamID = v
+	}
+	if v := os.Getenv("GOIOS_APP_ID"); v != "" {
+		config.AppIDPrefix = v
+	}
+
+	return config, nil
+}
+
+// findSigningIdentities parses `security find-identity -v -p codesigning`
+// for valid Apple Development / iPhone Developer certificates.
+func findSigningIdentities() ([]signingIdentity, error) {
+	output, err := RunShellCommand("security", "find-identity", "-v", "-p", "codesigning")
+	if err != nil {
+		return nil, fmt.Errorf("error listing signing identities: %s", err)
+	}
+
+	var identities []signingIdentity
+	for _, match := range identityRe.FindAllStringSubmatch(output, -1) {
+		identities = append(identities, signingIdentity{SHA1: match[1], CommonName: match[2]})
+	}
+	if len(identities) == 0 {
+		return nil, fmt.Errorf("no valid Apple Development or iPhone Developer signing identities found")
+	}
+	return identities, nil
+}
+
+// findProvisioningProfiles decodes every *.mobileprovision under
+// ~/Library/MobileDevice/Provisioning Profiles and extracts the fields
+// xcodebuild needs to pick one non-interactively.
+func findProvisioningProfiles() ([]provisioningProfile, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("error resolving home directory: %s", err)
+	}
+
+	dir := filepath.Join(home, "Library", "MobileDevice", "Provisioning Profiles")
+	files, err := filepath.Glob(filepath.Join(dir, "*.mobileprovision"))
+	if err != nil {
+		return nil, fmt.Errorf("error listing provisioning profiles: %s", err)
+	}
+
+	var profiles []provisioningProfile
+	for _, file := range files {
+		plist, err := RunShellCommand("security", "cms", "-D", "-i", file)
+		if err != nil {
+			continue
+		}
+		profiles = append(profiles, parseProvisioningProfile(plist))
+	}
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("no provisioning profiles found in %s", dir)
+	}
+	return profiles, nil
+}
+
+func parseProvisioningProfile(plist string) provisioningProfile {
+	appID := plistString(plist, "application-identifier")
+	return provisioningProfile{
+		UUID:        plistString(plist, "UUID"),
+		TeamID:      plistString(plist, "TeamIdentifier"),
+		AppIDPrefix: appIDPrefix(appID),
+		ExpiresAt:   plistString(plist, "ExpirationDate"),
+		CommonName:  plistString(plist, "Name"),
+		CertSHA1s:   plistCertSHA1s(plist),
+	}
+}
+
+// appIDPrefix extracts the team prefix from an application-identifier value
+// like "ABCDE12345.com.example.app".
+func appIDPrefix(appID string) string {
+	prefix, _, _ := strings.Cut(appID, ".")
+	return prefix
+}
+
+// plistString returns the string value of the first <string> or <date>
+// element that follows a <key>name</key> element in an XML plist,
+// regardless of whether it's wrapped in an <array> (as TeamIdentifier is).
+func plistString(plist, key string) string {
+	keyIdx := strings.Index(plist, fmt.Sprintf("<key>%s</key>", key))
+	if keyIdx == -1 {
+		return ""
+	}
+	re := regexp.MustCompile(`(?s)<(?:string|date)>(.*?)</(?:string|date)>`)
+	match := re.FindStringSubmatch(plist[keyIdx:])
+	if match == nil {
+		return ""
+	}
+	return strings.TrimSpace(match[1])
+}
+
+// plistCertSHA1s returns the SHA1 hashes of every DER certificate embedded
+// in the profile's DeveloperCertificates array, so a profile can be matched
+// against a `security find-identity` SHA1.
+func plistCertSHA1s(plist string) []string {
+	arrayRe := regexp.MustCompile(`(?s)<key>DeveloperCertificates</key>\s*<array>(.*?)</array>`)
+	arrayMatch := arrayRe.FindStringSubmatch(plist)
+	if arrayMatch == nil {
+		return nil
+	}
+
+	dataRe := regexp.MustCompile(`(?s)<data>(.*?)</data>`)
+	var hashes []string
+	for _, dataMatch := range dataRe.FindAllStringSubmatch(arrayMatch[1], -1) {
+		raw := strings.Join(strings.Fields(dataMatch[1]), "")
+		der, err := base64.StdEncoding.DecodeString(raw)
+		if err != nil {
+			continue
+		}
+		sum := sha1.Sum(der)
+		hashes = append(hashes, strings.ToUpper(hex.EncodeToString(sum[:])))
+	}
+	return hashes
+}
+
+func selectIdentity(identities []signingIdentity) (signingIdentity, error) {
+	if len(identities) == 1 {
+		return identities[0], nil
+	}
+
+	labels := make([]string, len(identities))
+	for i, id := range identities {
+		labels[i] = fmt.Sprintf("%s (%s)", id.CommonName, id.SHA1)
+	}
+
+	prompt := promptui.Select{Label: "Select a Signing Identity", Items: labels}
+	index, _, err := prompt.Run()
+	if err != nil {
+		return signingIdentity{}, fmt.Errorf("error selecting signing identity: %s", err)
+	}
+	return identities[index], nil
+}
+
+func selectProfile(profiles []provisioningProfile, identity signingIdentity) (provisioningProfile, error) {
+	var matching []provisioningProfile
+	for _, p := range profiles {
+		if containsString(p.CertSHA1s, identity.SHA1) {
+			matching = append(matching, p)
+		}
+	}
+	if len(matching) > 0 {
+		profiles = matching
+	}
+
+	if len(profiles) == 1 {
+		return profiles[0], nil
+	}
+
+	labels := make([]string, len(profiles))
+	for i, p := range profiles {
+		labels[i] = fmt.Sprintf("%s (team %s, expires %s)", p.CommonName, p.TeamID, p.ExpiresAt)
+	}
+
+	prompt := promptui.Select{Label: "Select a Provisioning Profile", Items: labels}
+	index, _, err := prompt.Run()
+	if err != nil {
+		return provisioningProfile{}, fmt.Errorf("error selecting provisioning profile: %s", err)
+	}
+	return profiles[index], nil
+}
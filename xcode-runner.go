@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"os/exec"
@@ -67,14 +68,15 @@ func GetSchemes() ([]string, error) {
 	return schemes, nil
 }
 
-func GetDevices() (map[string]string, error) {
+func GetDevices() (map[string]string, map[string]bool, error) {
 	cmd := exec.Command("xcrun", "xctrace", "list", "devices")
 	output, err := cmd.Output()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	devices := make(map[string]string)
+	isSimulator := make(map[string]bool)
 	re := regexp.MustCompile(`^(.+) \(([A-F0-9]{8}-[A-F0-9]{4}-[A-F0-9]{4}-[A-F0-9]{4}-[A-F0-9]{12}|[0-9]{8}-[0-9]{16})\)$`)
 
 	scanner := bufio.NewScanner(strings.NewReader(string(output)))
@@ -117,15 +119,16 @@ func GetDevices() (map[string]string, error) {
 			// Check if the UDID matches the expected format
 			if matched := re.MatchString(line); matched || (len(udid) > 0 && (strings.Contains(udid, "-") || strings.ContainsAny(udid, "0123456789ABCDEF"))) {
 				devices[deviceName] = udid
+				isSimulator[udid] = currentSection == "== Simulators =="
 			}
 		}
 	}
 
 	if len(devices) == 0 {
-		return nil, fmt.Errorf("no available simulators found")
+		return nil, nil, fmt.Errorf("no available simulators found")
 	}
 
-	return devices, nil
+	return devices, isSimulator, nil
 }
 
 func PromptUser(label string, items []string) (string, error) {
@@ -141,6 +144,20 @@ func PromptUser(label string, items []string) (string, error) {
 }
 
 func main() {
+	lldbFlag := flag.Bool("lldb", false, "attach an interactive LLDB session to the launched app instead of just running it")
+	devicesFlag := flag.String("devices", "", "comma-separated device names/UDIDs to deploy to, or \"all-sims\"/\"all-devices\"")
+	logsFlag := flag.Bool("logs", false, "stream logs from the launched app after deploying")
+	logFilterFlag := flag.String("log-filter", "", "regex of log lines to drop when streaming with -logs")
+	useLastFlag := flag.Bool("use-last", false, "reuse the scheme/device/configuration cached in "+configFileName)
+	jsonFlag := flag.Bool("json", false, "emit a machine-readable build summary (errors/warnings/duration) as JSON")
+	flag.Parse()
+
+	cfg, err := LoadWorkspaceConfig()
+	if err != nil {
+		fmt.Println("❌ Error loading workspace config:", err)
+		return
+	}
+
 	fmt.Println("🚀 Xcode Runner CLI")
 
 	projectPath, err := detectXcodeProject()
@@ -155,14 +172,18 @@ func main() {
 		fmt.Println("❌ Error fetching schemes:", err)
 		return
 	}
-	selectedScheme := schemes[0]
-	// selectedScheme, err := PromptUser("Select a Scheme", schemes)
-	// if err != nil {
-	// 	fmt.Println("❌ Error selecting scheme:", err)
-	// 	return
-	// }
+	var selectedScheme string
+	if *useLastFlag && cfg.Scheme != "" && containsString(schemes, cfg.Scheme) {
+		selectedScheme = cfg.Scheme
+	} else {
+		selectedScheme, err = PromptUser("Select a Scheme", schemes)
+		if err != nil {
+			fmt.Println("❌ Error selecting scheme:", err)
+			return
+		}
+	}
 
-	devices, err := GetDevices()
+	devices, isSimulator, err := GetDevices()
 	if err != nil {
 		fmt.Println("❌ Error fetching devices:", err)
 		return
@@ -173,22 +194,68 @@ func main() {
 		deviceNames = append(deviceNames, name)
 	}
 
-	selectedDevice, err := PromptUser("Select a Device", deviceNames)
-	if err != nil {
-		fmt.Println("❌ Error selecting device:", err)
-		return
+	deviceNameByUDID := make(map[string]string, len(devices))
+	for name, udid := range devices {
+		deviceNameByUDID[udid] = name
 	}
-	deviceUDID, found := devices[selectedDevice]
-	if !found {
-		fmt.Println("❌ Error: Could not find UDID for selected device.")
-		return
+
+	var deployUDIDs []string
+	var deviceUDID string
+	if *devicesFlag != "" {
+		deployUDIDs, err = ResolveDeployTargets(*devicesFlag, devices, isSimulator)
+		if err != nil {
+			fmt.Println("❌ Error resolving -devices:", err)
+			return
+		}
+		deviceUDID = deployUDIDs[0]
+	} else if *useLastFlag && cfg.DeviceUDID != "" {
+		if _, found := deviceNameByUDID[cfg.DeviceUDID]; !found {
+			fmt.Println("❌ Error: cached device", cfg.DeviceUDID, "is no longer available.")
+			return
+		}
+		deviceUDID = cfg.DeviceUDID
+		deployUDIDs = []string{cfg.DeviceUDID}
+	} else {
+		selectedDevice, err := PromptUser("Select a Device", deviceNames)
+		if err != nil {
+			fmt.Println("❌ Error selecting device:", err)
+			return
+		}
+		udid, found := devices[selectedDevice]
+		if !found {
+			fmt.Println("❌ Error: Could not find UDID for selected device.")
+			return
+		}
+		deviceUDID = udid
+		deployUDIDs = []string{udid}
 	}
 
-	fmt.Printf("\n🔨 Building %s for %s (%s)...\n", selectedScheme, selectedDevice, deviceUDID)
+	var configuration string
+	if *useLastFlag && cfg.Configuration != "" {
+		configuration = cfg.Configuration
+	} else {
+		configuration, err = PromptUser("Select a Configuration", []string{"Debug", "Release"})
+		if err != nil {
+			fmt.Println("❌ Error selecting configuration:", err)
+			return
+		}
+	}
+
+	cfg.Scheme = selectedScheme
+	cfg.DeviceUDID = deviceUDID
+	cfg.Configuration = configuration
+	if err := SaveWorkspaceConfig(cfg); err != nil {
+		fmt.Println("⚠️ Warning: could not save workspace config:", err)
+	}
+
+	fmt.Printf("\n🔨 Building %s for %s (%s)...\n", selectedScheme, deviceNameByUDID[deviceUDID], deviceUDID)
 
 	appPath, bundleIdentifier, err := GetBuildSettings(selectedScheme, deviceUDID)
 	if err != nil {
 		fmt.Println("❌ Error getting build settings:", err)
+		if hint := BuildSettingsErrorHint(err, schemes); hint != "" {
+			fmt.Println(hint)
+		}
 		return
 	}
 	if appPath == "" || bundleIdentifier == "" {
@@ -198,35 +265,107 @@ func main() {
 
 	isSim := strings.Contains(appPath, "simulator")
 
-	buildCmd := exec.Command("xcodebuild",
+	destination := "id=" + deviceUDID
+	if cfg.Destination != "" {
+		destination = cfg.Destination
+	}
+
+	override := cfg.SchemeOverrides[selectedScheme]
+
+	buildArgs := []string{
 		"-scheme", selectedScheme,
-		"-destination",
-		"id="+deviceUDID,
-		"-configuration", "Debug",
-		"build")
-	buildCmd.Stdout = os.Stdout
-	buildCmd.Stderr = os.Stderr
-	err = buildCmd.Run()
+		"-destination", destination,
+		"-configuration", configuration,
+	}
+	if override.XCConfigPath != "" {
+		buildArgs = append(buildArgs, "-xcconfig", override.XCConfigPath)
+	}
+	buildArgs = append(buildArgs, override.ExtraArgs...)
+	if !isSim {
+		signing, err := DetectSigning()
+		if err != nil {
+			fmt.Println("❌ Error detecting signing configuration:", err)
+			return
+		}
+		buildArgs = append(buildArgs,
+			"DEVELOPMENT_TEAM="+signing.TeamID,
+			"CODE_SIGN_IDENTITY="+signing.DevID,
+			"PROVISIONING_PROFILE_SPECIFIER="+signing.ProfileUUID,
+			"AppIdentifierPrefix="+signing.AppIDPrefix+".",
+		)
+	}
+	releaseLock, err := AcquireBuildLock()
 	if err != nil {
+		fmt.Println("❌ Error acquiring build lock:", err)
+		return
+	}
+	defer releaseLock()
+	summary, buildErr := RunBuild(buildArgs)
+
+	if *jsonFlag {
+		encoded, _ := json.MarshalIndent(summary, "", "  ")
+		fmt.Println(string(encoded))
+	}
+	if buildErr != nil {
 		fmt.Println("❌ Build failed!")
 		return
 	}
 
-	if isSim {
-		fmt.Println("\n📲 Installing & Launching App on Simulator...")
-		exec.Command("xcrun", "simctl", "bootstatus", deviceUDID, "-b").Run()
-		exec.Command("xcrun", "simctl", "install", deviceUDID, appPath).Run()
-		exec.Command("xcrun", "simctl", "launch", deviceUDID, bundleIdentifier).Run()
-	} else {
+	if *lldbFlag {
+		if len(deployUDIDs) > 1 {
+			fmt.Println("❌ -lldb is only supported with a single deploy target")
+			return
+		}
+		if isSim {
+			fmt.Println("\n📲 Installing & Launching App on Simulator...")
+			exec.Command("xcrun", "simctl", "bootstatus", deviceUDID, "-b").Run()
+			exec.Command("xcrun", "simctl", "install", deviceUDID, appPath).Run()
+			err = DebugSimulator(deviceUDID, bundleIdentifier)
+		} else {
+			fmt.Println("\n🔗 Deploying to Physical Device...")
+			exec.Command("xcrun", "devicectl", "device", "install", "app", "--device", deviceUDID, "--bundle", appPath).Run()
+			err = DebugDevice(deviceUDID, bundleIdentifier)
+		}
+		if err != nil {
+			fmt.Println("❌ Error starting LLDB session:", err)
+			return
+		}
+		fmt.Println("\n✅ Done!")
+		return
+	}
+
+	if *logsFlag && len(deployUDIDs) > 1 {
+		fmt.Println("❌ -logs is only supported with a single deploy target")
+		return
+	}
+
+	var devicePID int
+	if *logsFlag && !isSim {
 		fmt.Println("\n🔗 Deploying to Physical Device...")
-		// _, err := exec.LookPath("ios-deploy")
-		// if err != nil {
-		// 	fmt.Println("❌ ios-deploy not found. Install it with: brew install ios-deploy")
-		// 	return
-		// }
-		// exec.Command("ios-deploy", "--bundle", appPath, "--id", deviceUDID, "--debug").Run()
 		exec.Command("xcrun", "devicectl", "device", "install", "app", "--device", deviceUDID, "--bundle", appPath).Run()
-		exec.Command("xcrun", "devicectl", "device", "process", "launch", "--device", deviceUDID, "--start-stopped", bundleIdentifier).Run()
+		devicePID, err = launchDeviceCapturingPID(deviceUDID, bundleIdentifier)
+		if err != nil {
+			fmt.Println("❌ Error launching app:", err)
+			return
+		}
+	} else {
+		if isSim {
+			fmt.Printf("\n📲 Installing & Launching App on %d Simulator(s)...\n", len(deployUDIDs))
+		} else {
+			fmt.Printf("\n🔗 Deploying to %d Physical Device(s)...\n", len(deployUDIDs))
+		}
+		if err := DeployAll(deployUDIDs, deviceNameByUDID, isSim, appPath, bundleIdentifier, override.envArgs()); err != nil {
+			fmt.Println("❌ One or more deployments failed:")
+			fmt.Println(err)
+			return
+		}
+	}
+
+	if *logsFlag {
+		if err := StreamLogs(*logFilterFlag, isSim, deviceUDID, bundleIdentifier, devicePID); err != nil {
+			fmt.Println("❌ Error streaming logs:", err)
+			return
+		}
 	}
 
 	fmt.Println("\n✅ Done!")
@@ -262,10 +401,14 @@ func detectXcodeProject() (string, error) {
 
 func GetBuildSettings(selectedScheme, deviceID string) (string, string, error) {
 	cmd := exec.Command("xcodebuild", "-scheme", selectedScheme, "-destination", fmt.Sprintf("id=%s", deviceID), "-showBuildSettings", "-json")
-	var out bytes.Buffer
+	var out, stderr bytes.Buffer
 	cmd.Stdout = &out
+	cmd.Stderr = &stderr
 	err := cmd.Run()
 	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", "", fmt.Errorf("%s: %s", err, msg)
+		}
 		return "", "", err
 	}
 